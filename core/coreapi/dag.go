@@ -0,0 +1,298 @@
+package coreapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	iface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	"github.com/ipfs/go-ipfs/merkledag"
+	"github.com/ipfs/go-ipfs/pin"
+
+	cid "gx/ipfs/QmNp85zy9RLrQ5oQD4hPyS39ezrrXpcaa7R4Y9kxdWQLLQ/go-cid"
+	ipld "gx/ipfs/QmPN7cwmpcc4DWXb4KTB9dNAJgjuPY69h3npsMfhRrQL9c/go-ipld-format"
+	car "gx/ipfs/QmUjmKtXtvNyEyhZBTeNnDgKQgJWoKvc3Dn9DjZkQLnDHz/go-car"
+)
+
+// DagAPI implements iface.DagAPI.
+type DagAPI struct {
+	dag     merkledag.DAGService
+	pinning pin.Pinner
+}
+
+// NewDagAPI builds a DagAPI backed by dag for block storage/traversal and
+// pinning for root pinning.
+func NewDagAPI(dag merkledag.DAGService, pinning pin.Pinner) *DagAPI {
+	return &DagAPI{dag: dag, pinning: pinning}
+}
+
+func (api *DagAPI) Import(ctx context.Context, r io.Reader, opts ...iface.DagImportOption) (<-chan iface.ImportEvent, error) {
+	settings := new(iface.DagImportSettings)
+	for _, opt := range opts {
+		opt(settings)
+	}
+
+	cr, err := car.NewCarReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan iface.ImportEvent, len(cr.Header.Roots))
+
+	go func() {
+		defer close(events)
+
+		batch := merkledag.NewBatchWithContext(ctx, api.dag)
+		defer batch.Close()
+		stats := &iface.DagImportStats{}
+
+		for {
+			blk, err := cr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				events <- iface.ImportEvent{Err: err}
+				return
+			}
+
+			nd, err := ipld.Decode(blk)
+			if err != nil {
+				events <- iface.ImportEvent{Err: err}
+				return
+			}
+
+			if _, err := batch.Add(ctx, nd); err != nil {
+				events <- iface.ImportEvent{Err: err}
+				return
+			}
+
+			stats.NumBlocks++
+			stats.NumBytes += int64(len(blk.RawData()))
+		}
+
+		if err := batch.Commit(ctx); err != nil {
+			events <- iface.ImportEvent{Err: err}
+			return
+		}
+
+		for i, root := range cr.Header.Roots {
+			root := root
+			evt := iface.ImportEvent{Root: root}
+
+			// Fetch the root unconditionally: this is what catches a
+			// truncated or corrupt CAR whose root block never made it
+			// into the stream, regardless of whether the caller asked
+			// us to pin it.
+			nd, err := api.dag.Get(ctx, root)
+			if err != nil {
+				evt.Err = err
+			} else if settings.Pin {
+				if err := api.pinning.Pin(ctx, nd, true); err != nil {
+					evt.Err = err
+				} else if err := api.pinning.Flush(); err != nil {
+					evt.Err = err
+				}
+			}
+
+			if settings.Stats && i == len(cr.Header.Roots)-1 {
+				evt.Stats = stats
+			}
+
+			events <- evt
+		}
+	}()
+
+	return events, nil
+}
+
+func (api *DagAPI) Export(ctx context.Context, paths []iface.Path, w io.Writer) error {
+	roots := make([]*cid.Cid, 0, len(paths))
+	for _, p := range paths {
+		roots = append(roots, p.Cid())
+	}
+
+	cw, err := car.NewCarWriter(w, &car.CarHeader{Roots: roots, Version: 1})
+	if err != nil {
+		return err
+	}
+
+	seen := cid.NewSet()
+	for _, root := range roots {
+		if err := api.exportDFS(ctx, root, seen, cw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (api *DagAPI) Stat(ctx context.Context, p iface.Path, opts ...iface.DagStatOption) (*iface.DagStat, error) {
+	settings := new(iface.DagStatSettings)
+	for _, opt := range opts {
+		opt(settings)
+	}
+
+	workers := runtime.NumCPU()
+
+	// incoming is fed directly by workers as they discover links; jobs is
+	// what the worker pool ranges over. A dedicated pump goroutine sits
+	// between the two with an unboundedly growing queue so that a worker
+	// enqueueing a wide node (HAMT-sharded directories commonly fan out
+	// 256-way, well past workers*4) is never stuck waiting for a sibling
+	// worker to free up receiving capacity on a bounded channel.
+	incoming := make(chan *cid.Cid)
+	jobs := make(chan *cid.Cid)
+	go pumpQueue(ctx, incoming, jobs)
+
+	var (
+		mu       sync.Mutex
+		seen     = cid.NewSet()
+		stat     = &iface.DagStat{}
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	fail := func(err error) {
+		once.Do(func() { firstErr = err })
+	}
+
+	enqueue := func(c *cid.Cid) {
+		mu.Lock()
+		stat.NumBlocks++
+		isNew := seen.Visit(c)
+		if isNew {
+			stat.UniqueBlocks = seen.Len()
+		}
+		mu.Unlock()
+		if !isNew {
+			return
+		}
+		wg.Add(1)
+		select {
+		case incoming <- c:
+		case <-ctx.Done():
+			wg.Done()
+		}
+	}
+
+	var workerWg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		workerWg.Add(1)
+		go func() {
+			defer workerWg.Done()
+			for c := range jobs {
+				nd, err := api.dag.Get(ctx, c)
+				if err != nil {
+					fail(fmt.Errorf("stat: fetching %s: %s", c, err))
+					wg.Done()
+					continue
+				}
+
+				links := nd.Links()
+
+				mu.Lock()
+				stat.Size += uint64(len(nd.RawData()))
+				stat.NumLinks += len(links)
+				progress := iface.DagStatProgress{NumBlocks: stat.NumBlocks, Size: stat.Size}
+				mu.Unlock()
+
+				if settings.Progress != nil {
+					select {
+					case settings.Progress <- progress:
+					case <-ctx.Done():
+					}
+				}
+
+				for _, l := range links {
+					enqueue(l.Cid)
+				}
+				wg.Done()
+			}
+		}()
+	}
+
+	enqueue(p.Cid())
+	go func() {
+		wg.Wait()
+		close(incoming)
+	}()
+	workerWg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return stat, nil
+}
+
+// pumpQueue bridges in, which workers send newly-discovered CIDs to
+// directly, and out, which the worker pool ranges over, via a slice-backed
+// queue that grows as needed instead of a fixed-capacity channel. It
+// returns once in is closed and every buffered item has been forwarded, or
+// ctx is canceled.
+func pumpQueue(ctx context.Context, in <-chan *cid.Cid, out chan<- *cid.Cid) {
+	defer close(out)
+
+	var queue []*cid.Cid
+	for {
+		if len(queue) == 0 {
+			c, ok := <-in
+			if !ok {
+				return
+			}
+			queue = append(queue, c)
+			continue
+		}
+
+		select {
+		case c, ok := <-in:
+			if !ok {
+				for _, c := range queue {
+					select {
+					case out <- c:
+					case <-ctx.Done():
+						return
+					}
+				}
+				return
+			}
+			queue = append(queue, c)
+		case out <- queue[0]:
+			queue = queue[1:]
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// exportDFS walks the DAG rooted at c depth-first, writing every
+// not-yet-seen block to cw.
+func (api *DagAPI) exportDFS(ctx context.Context, c *cid.Cid, seen *cid.Set, cw *car.CarWriter) error {
+	if !seen.Visit(c) {
+		return nil
+	}
+
+	nd, err := api.dag.Get(ctx, c)
+	if err != nil {
+		return fmt.Errorf("export: fetching %s: %s", c, err)
+	}
+
+	if err := cw.WriteBlock(nd); err != nil {
+		return err
+	}
+
+	for _, link := range nd.Links() {
+		if err := api.exportDFS(ctx, link.Cid, seen, cw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
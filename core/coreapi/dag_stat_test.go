@@ -0,0 +1,121 @@
+package coreapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-ipfs/merkledag"
+)
+
+// newTestNode builds a leaf ProtoNode carrying data and stores it in dag.
+func newTestNode(t *testing.T, ctx context.Context, dag merkledag.DAGService, data string) *merkledag.ProtoNode {
+	t.Helper()
+	nd := merkledag.NodeWithData([]byte(data))
+	if err := dag.Add(ctx, nd); err != nil {
+		t.Fatalf("adding node %q: %s", data, err)
+	}
+	return nd
+}
+
+// TestStatWideFanoutDoesNotHang guards against a deadlock where a worker
+// enqueueing a node with many more links than the worker pool's capacity
+// (as a HAMT-sharded directory commonly has) could block forever with no
+// sibling worker left in receiving position. See pumpQueue.
+func TestStatWideFanoutDoesNotHang(t *testing.T) {
+	ctx := context.Background()
+	dag := testDagService()
+	api := NewDagAPI(dag, nil)
+
+	const fanout = 512 // comfortably above runtime.NumCPU()*4 on any CI box
+	root := merkledag.NodeWithData([]byte("root"))
+	for i := 0; i < fanout; i++ {
+		leaf := newTestNode(t, ctx, dag, fmt.Sprintf("leaf-%d", i))
+		if err := root.AddNodeLink(fmt.Sprintf("leaf-%d", i), leaf); err != nil {
+			t.Fatalf("linking leaf %d: %s", i, err)
+		}
+	}
+	if err := dag.Add(ctx, root); err != nil {
+		t.Fatalf("adding root: %s", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stat, err := api.Stat(ctx, newResolvedPath(root.Cid()))
+		if err != nil {
+			t.Errorf("stat: %s", err)
+			return
+		}
+		if stat.NumBlocks != fanout+1 {
+			t.Errorf("expected %d blocks, got %d", fanout+1, stat.NumBlocks)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Stat did not return: likely deadlocked enqueueing a wide node")
+	}
+}
+
+// TestStatComputesSizeLinksAndUniqueBlocks checks Size, NumLinks,
+// NumBlocks, and UniqueBlocks against a small, known DAG where a leaf is
+// reachable from two different parents, so NumBlocks (every link reference
+// traversed) and UniqueBlocks (distinct blocks fetched) diverge.
+func TestStatComputesSizeLinksAndUniqueBlocks(t *testing.T) {
+	ctx := context.Background()
+	dag := testDagService()
+	api := NewDagAPI(dag, nil)
+
+	leaf := newTestNode(t, ctx, dag, "leaf")
+
+	parentA := merkledag.NodeWithData([]byte("parentA"))
+	if err := parentA.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatalf("linking leaf to parentA: %s", err)
+	}
+	if err := dag.Add(ctx, parentA); err != nil {
+		t.Fatalf("adding parentA: %s", err)
+	}
+
+	parentB := merkledag.NodeWithData([]byte("parentB"))
+	if err := parentB.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatalf("linking leaf to parentB: %s", err)
+	}
+	if err := dag.Add(ctx, parentB); err != nil {
+		t.Fatalf("adding parentB: %s", err)
+	}
+
+	root := merkledag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("parentA", parentA); err != nil {
+		t.Fatalf("linking parentA: %s", err)
+	}
+	if err := root.AddNodeLink("parentB", parentB); err != nil {
+		t.Fatalf("linking parentB: %s", err)
+	}
+	if err := dag.Add(ctx, root); err != nil {
+		t.Fatalf("adding root: %s", err)
+	}
+
+	wantSize := uint64(len(root.RawData()) + len(parentA.RawData()) + len(parentB.RawData()) + len(leaf.RawData()))
+
+	stat, err := api.Stat(ctx, newResolvedPath(root.Cid()))
+	if err != nil {
+		t.Fatalf("stat: %s", err)
+	}
+
+	if stat.UniqueBlocks != 4 {
+		t.Fatalf("expected 4 unique blocks (root, parentA, parentB, leaf), got %d", stat.UniqueBlocks)
+	}
+	// root, parentA, parentB, plus leaf referenced once from each parent.
+	if stat.NumBlocks != 5 {
+		t.Fatalf("expected 5 total block references (leaf counted twice), got %d", stat.NumBlocks)
+	}
+	if stat.NumLinks != 4 {
+		t.Fatalf("expected 4 link entries (root->parentA, root->parentB, parentA->leaf, parentB->leaf), got %d", stat.NumLinks)
+	}
+	if stat.Size != wantSize {
+		t.Fatalf("expected size %d, got %d", wantSize, stat.Size)
+	}
+}
@@ -0,0 +1,112 @@
+package coreapi
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	iface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	"github.com/ipfs/go-ipfs/merkledag"
+	"github.com/ipfs/go-ipfs/pin"
+
+	ds "gx/ipfs/QmVSase1JP7cq9QkPT46y8PflNaL1VuXBpxVM8fD1pPCyQ/go-datastore"
+	bserv "gx/ipfs/QmYPZzd9VqmJDwxUnThfeSbV1Y5o53aVPDijTB7j7rS9Ep/go-blockservice"
+	offline "gx/ipfs/QmYZwey1rKQE4vNgE2taWsLHwDCvxP4N1e2RU2HyHgKfu3/go-ipfs-exchange-offline"
+	blockstore "gx/ipfs/QmadMhXJLHMFjpRmh85XjpmVDkEtQpNYEZNRpWsKq7w1qG/go-ipfs-blockstore"
+)
+
+// testDagService returns an empty in-memory DAGService, fresh for each
+// caller.
+func testDagService() merkledag.DAGService {
+	bs := blockstore.NewBlockstore(ds.NewMapDatastore())
+	return merkledag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+}
+
+func TestCarExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	src := NewDagAPI(testDagService(), pin.NewPinner())
+
+	leaf := merkledag.NodeWithData([]byte("leaf"))
+	if err := src.dag.Add(ctx, leaf); err != nil {
+		t.Fatalf("seeding leaf: %s", err)
+	}
+	root := merkledag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatalf("linking leaf: %s", err)
+	}
+	if err := src.dag.Add(ctx, root); err != nil {
+		t.Fatalf("seeding root: %s", err)
+	}
+
+	var car bytes.Buffer
+	if err := src.Export(ctx, []iface.Path{newResolvedPath(root.Cid())}, &car); err != nil {
+		t.Fatalf("export: %s", err)
+	}
+
+	dst := NewDagAPI(testDagService(), pin.NewPinner())
+	events, err := dst.Import(ctx, &car, iface.WithDagImportPin(true), iface.WithDagImportStats(true))
+	if err != nil {
+		t.Fatalf("import: %s", err)
+	}
+
+	var n int
+	for evt := range events {
+		n++
+		if evt.Err != nil {
+			t.Fatalf("import event: %s", evt.Err)
+		}
+		if !evt.Root.Equals(root.Cid()) {
+			t.Fatalf("unexpected root in import event: %s", evt.Root)
+		}
+		if evt.Stats == nil {
+			t.Fatalf("expected stats on the final import event")
+		}
+		if evt.Stats.NumBlocks != 2 {
+			t.Fatalf("expected 2 blocks imported, got %d", evt.Stats.NumBlocks)
+		}
+	}
+	if n != 1 {
+		t.Fatalf("expected exactly one root event, got %d", n)
+	}
+
+	if _, err := dst.dag.Get(ctx, leaf.Cid()); err != nil {
+		t.Fatalf("leaf missing after import: %s", err)
+	}
+}
+
+func TestCarImportMissingRootBlockReportsError(t *testing.T) {
+	ctx := context.Background()
+
+	src := NewDagAPI(testDagService(), pin.NewPinner())
+	root := merkledag.NodeWithData([]byte("unreachable-after-export"))
+	if err := src.dag.Add(ctx, root); err != nil {
+		t.Fatalf("seeding root: %s", err)
+	}
+
+	var car bytes.Buffer
+	if err := src.Export(ctx, []iface.Path{newResolvedPath(root.Cid())}, &car); err != nil {
+		t.Fatalf("export: %s", err)
+	}
+
+	// Corrupt the stream so the root block never makes it across.
+	truncated := bytes.NewReader(car.Bytes()[:len(car.Bytes())/2])
+
+	dst := NewDagAPI(testDagService(), pin.NewPinner())
+	events, err := dst.Import(ctx, truncated)
+	if err != nil {
+		// A header-level failure is an acceptable way to reject a
+		// truncated stream too.
+		return
+	}
+
+	var sawErr bool
+	for evt := range events {
+		if evt.Err != nil {
+			sawErr = true
+		}
+	}
+	if !sawErr {
+		t.Fatalf("expected Import to report an error for a truncated CAR, got none")
+	}
+}
@@ -14,10 +14,19 @@ type Path interface {
 	Cid() *cid.Cid
 	Root() *cid.Cid
 	Resolved() bool
+
+	// Namespace returns the path's resolution scheme: "ipfs", "ipns", or
+	// "ipld".
+	Namespace() string
+
+	// Remainder returns the portion of the path after Root, with no
+	// leading or trailing slash. Empty for a path that is just a key.
+	Remainder() string
 }
 
 // TODO: should we really copy these?
-//       if we didn't, godoc would generate nice links straight to go-ipld-format
+//
+//	if we didn't, godoc would generate nice links straight to go-ipld-format
 type Node ipld.Node
 type Link ipld.Link
 
@@ -28,17 +37,197 @@ type Reader interface {
 
 type CoreAPI interface {
 	Unixfs() UnixfsAPI
+	Dag() DagAPI
+	Resolver() Resolver
 	ResolvePath(context.Context, Path) (Path, error)
 	ResolveNode(context.Context, Path) (Node, error)
 }
 
+// Resolver walks a Path through the DAG, turning it into the links and
+// nodes traversed along the way, or into a terminal CID. It exists so that
+// callers (cat, ls, pin, files, object, ...) stop re-implementing the same
+// path->CID walk at every command site.
+type Resolver interface {
+	// ResolveLinks resolves as much of p's remainder as possible by
+	// following links from p's root, returning every link traversed and
+	// whatever path segments are left unresolved. A non-empty remainder
+	// means the last link traversed doesn't itself name another link,
+	// e.g. it names a field inside a node's own data.
+	ResolveLinks(context.Context, Path) ([]*Link, []string, error)
+
+	// ResolvePathComponents resolves p one segment at a time, returning
+	// the Node found at p's root and at every successfully traversed
+	// link in order.
+	ResolvePathComponents(context.Context, Path) ([]Node, error)
+
+	// ResolveToCid fully resolves p down to the CID of its target. It
+	// short-circuits immediately when p.Remainder() == "" instead of
+	// re-walking a path that is already just a key.
+	ResolveToCid(context.Context, Path) (*cid.Cid, error)
+}
+
+// DagAPI specifies the interface to CAR (Content Addressable aRchive)
+// import/export and whole-DAG inspection.
+type DagAPI interface {
+	// Import reads a CARv1 or CARv2 stream from r, storing every block it
+	// contains and, for each root listed in the CAR header, sending an
+	// ImportEvent on the returned channel once that root has finished
+	// importing. The channel is closed once the whole stream has been
+	// consumed.
+	Import(context.Context, io.Reader, ...DagImportOption) (<-chan ImportEvent, error)
+
+	// Export performs a deterministic DFS over the DAGs rooted at paths and
+	// writes them to w as a single CARv1 stream.
+	Export(context.Context, []Path, io.Writer) error
+
+	// Stat walks the whole DAG rooted at p and returns aggregate
+	// accounting across every block reachable from it. Unlike
+	// ObjectAPI.Stat, which only describes a single block, this covers the
+	// full DAG and is what pinning, gc planning, and bandwidth estimation
+	// need.
+	Stat(context.Context, Path, ...DagStatOption) (*DagStat, error)
+}
+
+// DagStat is the aggregate accounting produced by DagAPI.Stat.
+type DagStat struct {
+	// NumBlocks is the total number of block references encountered while
+	// walking the DAG, including repeat references to a block reachable by
+	// more than one path. It is a traversal count, not a count of distinct
+	// blocks.
+	NumBlocks int
+	Size      uint64
+	NumLinks  int
+
+	// UniqueBlocks is the number of distinct blocks actually fetched.
+	// Equal to NumBlocks unless the DAG re-references the same subtree more
+	// than once, which bandwidth and GC planning both care about.
+	UniqueBlocks int
+}
+
+// DagStatProgress is an incremental update sent on the channel passed to
+// WithDagStatProgress while a Stat walk is still running.
+type DagStatProgress struct {
+	NumBlocks int
+	Size      uint64
+}
+
+// DagStatSettings holds the options accepted by DagAPI.Stat.
+type DagStatSettings struct {
+	Progress chan<- DagStatProgress
+}
+
+// DagStatOption is a single setting applied to a DagAPI.Stat call.
+type DagStatOption func(*DagStatSettings)
+
+// WithDagStatProgress streams a DagStatProgress update on ch after every
+// block visited during the walk. ch is never closed by Stat; the caller
+// should stop reading once Stat returns.
+func WithDagStatProgress(ch chan<- DagStatProgress) DagStatOption {
+	return func(s *DagStatSettings) {
+		s.Progress = ch
+	}
+}
+
+// ImportEvent is emitted once per CAR root as DagAPI.Import finishes
+// processing it.
+type ImportEvent struct {
+	Root *cid.Cid
+	Err  error
+
+	// Stats is non-nil only when the import was started with
+	// WithDagImportStats(true) and this is the final event for the stream.
+	Stats *DagImportStats
+}
+
+// DagImportStats carries the accounting requested via WithDagImportStats(true).
+type DagImportStats struct {
+	NumBlocks int
+	NumBytes  int64
+}
+
+// DagImportSettings holds the options accepted by DagAPI.Import.
+type DagImportSettings struct {
+	Pin   bool
+	Stats bool
+}
+
+// DagImportOption is a single setting applied to a DagAPI.Import call.
+type DagImportOption func(*DagImportSettings)
+
+// WithDagImportPin controls whether each imported root is pinned
+// recursively once it has been fully ingested. Defaults to false.
+func WithDagImportPin(pin bool) DagImportOption {
+	return func(s *DagImportSettings) {
+		s.Pin = pin
+	}
+}
+
+// WithDagImportStats makes Import report a DagImportStats on the final
+// ImportEvent of the stream.
+func WithDagImportStats(enable bool) DagImportOption {
+	return func(s *DagImportSettings) {
+		s.Stats = enable
+	}
+}
+
 type UnixfsAPI interface {
-	Add(context.Context, io.Reader) (Path, error)
+	Add(context.Context, io.Reader, ...AddOption) (*AddResult, error)
 	Cat(context.Context, Path) (Reader, error)
 	Ls(context.Context, Path) ([]*Link, error)
 }
 
-//TODO: Should this use paths instead of cids?
+// AddResult is returned by UnixfsAPI.Add. For a plain add, Root is the only
+// populated field. When WithShardSize split the input across multiple
+// pin-roots, Shards and Meta are populated too.
+type AddResult struct {
+	Root Path
+
+	// Shards holds the root of every shard, in ingestion order. Nil unless
+	// the add was split via WithShardSize.
+	Shards []Path
+
+	// Meta is the cluster meta-root linking every entry in Shards. Equal
+	// to Root whenever Shards is set.
+	Meta Path
+
+	// ReplicationMin/ReplicationMax carry the bounds passed to
+	// WithShardReplication, for a cluster to honor when pinning Shards
+	// across peers. Zero when WithShardReplication was not used.
+	ReplicationMin int
+	ReplicationMax int
+}
+
+// AddSettings holds the options accepted by UnixfsAPI.Add.
+type AddSettings struct {
+	ShardSize      int64
+	ReplicationMin int
+	ReplicationMax int
+}
+
+// AddOption is a single setting applied to a UnixfsAPI.Add call.
+type AddOption func(*AddSettings)
+
+// WithShardSize caps each pin-root produced by Add at approximately size
+// cumulative bytes, splitting very large inputs (e.g. >1TB) into multiple
+// shards linked under a cluster meta-root, following the sharding model
+// used by ipfs-cluster. A size <= 0 (the default) disables sharding.
+func WithShardSize(size int64) AddOption {
+	return func(s *AddSettings) {
+		s.ShardSize = size
+	}
+}
+
+// WithShardReplication records the replication bounds a cluster should
+// apply when pinning the shards produced by WithShardSize; it has no
+// effect on a non-sharded Add.
+func WithShardReplication(min, max int) AddOption {
+	return func(s *AddSettings) {
+		s.ReplicationMin = min
+		s.ReplicationMax = max
+	}
+}
+
+// TODO: Should this use paths instead of cids?
 type ObjectAPI interface {
 	New(ctx context.Context) (Node, error)
 	Put(context.Context, Node) error
@@ -0,0 +1,24 @@
+package coreapi
+
+import (
+	cid "gx/ipfs/QmNp85zy9RLrQ5oQD4hPyS39ezrrXpcaa7R4Y9kxdWQLLQ/go-cid"
+)
+
+// resolvedPath is a trivial iface.Path backed directly by a CID. It's used
+// wherever an API hands back a Path for a root it just computed (e.g. a
+// freshly-added shard) rather than one a caller resolved through the
+// ipfs/ipns/ipld namespace.
+type resolvedPath struct {
+	c *cid.Cid
+}
+
+func newResolvedPath(c *cid.Cid) *resolvedPath {
+	return &resolvedPath{c: c}
+}
+
+func (p *resolvedPath) String() string    { return "/ipfs/" + p.c.String() }
+func (p *resolvedPath) Cid() *cid.Cid     { return p.c }
+func (p *resolvedPath) Root() *cid.Cid    { return p.c }
+func (p *resolvedPath) Resolved() bool    { return true }
+func (p *resolvedPath) Namespace() string { return "ipfs" }
+func (p *resolvedPath) Remainder() string { return "" }
@@ -0,0 +1,117 @@
+package coreapi
+
+import (
+	"context"
+	"strings"
+
+	iface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+
+	cid "gx/ipfs/QmNp85zy9RLrQ5oQD4hPyS39ezrrXpcaa7R4Y9kxdWQLLQ/go-cid"
+	ipld "gx/ipfs/QmPN7cwmpcc4DWXb4KTB9dNAJgjuPY69h3npsMfhRrQL9c/go-ipld-format"
+)
+
+// nodeGetter is the only dependency ResolveLinks and its helpers need:
+// fetching a node by CID. Resolver never batches or writes, so it depends
+// on this narrower surface rather than the full merkledag.DAGService;
+// merkledag.DAGService still satisfies it.
+type nodeGetter interface {
+	Get(ctx context.Context, c *cid.Cid) (ipld.Node, error)
+}
+
+// Resolver implements iface.Resolver.
+type Resolver struct {
+	dag nodeGetter
+}
+
+// NewResolver builds a Resolver that walks paths against dag.
+func NewResolver(dag nodeGetter) *Resolver {
+	return &Resolver{dag: dag}
+}
+
+func (r *Resolver) ResolveLinks(ctx context.Context, p iface.Path) ([]*iface.Link, []string, error) {
+	remainder := splitRemainder(p.Remainder())
+	if len(remainder) == 0 {
+		return nil, nil, nil
+	}
+
+	nd, err := r.dag.Get(ctx, p.Root())
+	if err != nil {
+		return nil, remainder, err
+	}
+
+	var links []*iface.Link
+	for len(remainder) > 0 {
+		lnk, rest, err := nd.ResolveLink(remainder)
+		if err != nil {
+			return links, remainder, err
+		}
+
+		if len(rest) == len(remainder) {
+			// No progress was made: the remainder names something inside
+			// the current node's own data rather than another link, so
+			// nothing was traversed this step.
+			return links, rest, nil
+		}
+
+		links = append(links, (*iface.Link)(lnk))
+		remainder = rest
+		if len(remainder) == 0 {
+			break
+		}
+
+		nd, err = r.dag.Get(ctx, lnk.Cid)
+		if err != nil {
+			return links, remainder, err
+		}
+	}
+
+	return links, remainder, nil
+}
+
+func (r *Resolver) ResolvePathComponents(ctx context.Context, p iface.Path) ([]iface.Node, error) {
+	nd, err := r.dag.Get(ctx, p.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []iface.Node{nd}
+	remainder := splitRemainder(p.Remainder())
+
+	for len(remainder) > 0 {
+		lnk, rest, err := nd.ResolveLink(remainder)
+		if err != nil {
+			return nodes, err
+		}
+		if len(rest) == len(remainder) {
+			break
+		}
+
+		nd, err = r.dag.Get(ctx, lnk.Cid)
+		if err != nil {
+			return nodes, err
+		}
+		nodes = append(nodes, nd)
+		remainder = rest
+	}
+
+	return nodes, nil
+}
+
+func (r *Resolver) ResolveToCid(ctx context.Context, p iface.Path) (*cid.Cid, error) {
+	if p.Remainder() == "" {
+		return p.Root(), nil
+	}
+
+	nodes, err := r.ResolvePathComponents(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return nodes[len(nodes)-1].Cid(), nil
+}
+
+func splitRemainder(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.Trim(s, "/"), "/")
+}
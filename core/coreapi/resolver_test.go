@@ -0,0 +1,203 @@
+package coreapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/merkledag"
+
+	cid "gx/ipfs/QmNp85zy9RLrQ5oQD4hPyS39ezrrXpcaa7R4Y9kxdWQLLQ/go-cid"
+	ipld "gx/ipfs/QmPN7cwmpcc4DWXb4KTB9dNAJgjuPY69h3npsMfhRrQL9c/go-ipld-format"
+)
+
+// testPath is a minimal iface.Path with an explicit remainder, for
+// exercising Resolver without going through a full path parser.
+type testPath struct {
+	root      *cid.Cid
+	remainder string
+}
+
+func (p *testPath) String() string    { return p.root.String() + "/" + p.remainder }
+func (p *testPath) Cid() *cid.Cid     { return p.root }
+func (p *testPath) Root() *cid.Cid    { return p.root }
+func (p *testPath) Resolved() bool    { return p.remainder == "" }
+func (p *testPath) Namespace() string { return "ipfs" }
+func (p *testPath) Remainder() string { return p.remainder }
+
+func TestResolverWalksNestedLinks(t *testing.T) {
+	ctx := context.Background()
+	dag := testDagService()
+
+	grandchild := newTestNode(t, ctx, dag, "grandchild")
+	child := merkledag.NodeWithData([]byte("child"))
+	if err := child.AddNodeLink("grandchild", grandchild); err != nil {
+		t.Fatalf("linking grandchild: %s", err)
+	}
+	if err := dag.Add(ctx, child); err != nil {
+		t.Fatalf("adding child: %s", err)
+	}
+	root := merkledag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("child", child); err != nil {
+		t.Fatalf("linking child: %s", err)
+	}
+	if err := dag.Add(ctx, root); err != nil {
+		t.Fatalf("adding root: %s", err)
+	}
+
+	r := NewResolver(dag)
+	p := &testPath{root: root.Cid(), remainder: "child/grandchild"}
+
+	links, remainder, err := r.ResolveLinks(ctx, p)
+	if err != nil {
+		t.Fatalf("ResolveLinks: %s", err)
+	}
+	if len(remainder) != 0 {
+		t.Fatalf("expected remainder to be fully consumed, got %v", remainder)
+	}
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links traversed, got %d", len(links))
+	}
+	if !links[0].Cid.Equals(child.Cid()) || !links[1].Cid.Equals(grandchild.Cid()) {
+		t.Fatalf("unexpected link order: %+v", links)
+	}
+
+	nodes, err := r.ResolvePathComponents(ctx, p)
+	if err != nil {
+		t.Fatalf("ResolvePathComponents: %s", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 nodes (root, child, grandchild), got %d", len(nodes))
+	}
+
+	c, err := r.ResolveToCid(ctx, p)
+	if err != nil {
+		t.Fatalf("ResolveToCid: %s", err)
+	}
+	if !c.Equals(grandchild.Cid()) {
+		t.Fatalf("expected ResolveToCid to land on grandchild, got %s", c)
+	}
+}
+
+func TestResolverShortCircuitsJustAKey(t *testing.T) {
+	ctx := context.Background()
+	dag := testDagService()
+	root := newTestNode(t, ctx, dag, "root")
+
+	// An empty DAG: any fetch would fail, so a pass means no walk happened.
+	r := NewResolver(testDagService())
+	p := &testPath{root: root.Cid()}
+
+	c, err := r.ResolveToCid(ctx, p)
+	if err != nil {
+		t.Fatalf("ResolveToCid: %s", err)
+	}
+	if !c.Equals(root.Cid()) {
+		t.Fatalf("expected ResolveToCid to return the root unchanged, got %s", c)
+	}
+}
+
+// fakeNode is a minimal ipld.Node whose ResolveLink is fully controlled by
+// the test, unlike merkledag.ProtoNode's, which never returns the
+// remainder unchanged with a nil error. That shape is exactly the "no
+// progress" case Resolver must handle: the remainder names a field inside
+// the node's own data rather than another link.
+type fakeNode struct {
+	c             *cid.Cid
+	onResolveLink func(path []string) (*ipld.Link, []string, error)
+}
+
+func (n *fakeNode) RawData() []byte { return []byte(n.c.String()) }
+func (n *fakeNode) Cid() *cid.Cid   { return n.c }
+func (n *fakeNode) String() string  { return "fakeNode " + n.c.String() }
+func (n *fakeNode) Loggable() map[string]interface{} {
+	return map[string]interface{}{"node": n.c.String()}
+}
+func (n *fakeNode) Links() []*ipld.Link                  { return nil }
+func (n *fakeNode) Tree(path string, depth int) []string { return nil }
+func (n *fakeNode) Copy() ipld.Node                      { return &fakeNode{c: n.c, onResolveLink: n.onResolveLink} }
+func (n *fakeNode) Size() (uint64, error)                { return uint64(len(n.RawData())), nil }
+func (n *fakeNode) Stat() (*ipld.NodeStat, error)        { return &ipld.NodeStat{}, nil }
+
+func (n *fakeNode) ResolveLink(path []string) (*ipld.Link, []string, error) {
+	return n.onResolveLink(path)
+}
+
+func (n *fakeNode) Resolve(path []string) (interface{}, []string, error) {
+	lnk, rest, err := n.onResolveLink(path)
+	return lnk, rest, err
+}
+
+// fakeNodeGetter serves fakeNodes by CID, letting a test control
+// ResolveLink behavior precisely instead of only exercising what
+// merkledag.ProtoNode happens to implement.
+type fakeNodeGetter struct {
+	nodes map[string]ipld.Node
+}
+
+func (f *fakeNodeGetter) Get(ctx context.Context, c *cid.Cid) (ipld.Node, error) {
+	nd, ok := f.nodes[c.String()]
+	if !ok {
+		return nil, fmt.Errorf("fakeNodeGetter: no node for %s", c)
+	}
+	return nd, nil
+}
+
+func TestResolverNoProgressNamesDataInsideNode(t *testing.T) {
+	ctx := context.Background()
+
+	rootCid := merkledag.NodeWithData([]byte("root")).Cid()
+	childCid := merkledag.NodeWithData([]byte("child")).Cid()
+
+	root := &fakeNode{c: rootCid}
+	root.onResolveLink = func(path []string) (*ipld.Link, []string, error) {
+		// "child" is root's one real link; anything past it names a field
+		// inside child's own data.
+		if path[0] != "child" {
+			return nil, nil, fmt.Errorf("no link named %q", path[0])
+		}
+		return &ipld.Link{Cid: childCid}, path[1:], nil
+	}
+
+	child := &fakeNode{c: childCid}
+	child.onResolveLink = func(path []string) (*ipld.Link, []string, error) {
+		// child has no links of its own: every remaining segment names
+		// something inside its data, so ResolveLink reports no progress.
+		return nil, path, nil
+	}
+
+	dag := &fakeNodeGetter{nodes: map[string]ipld.Node{
+		rootCid.String():  root,
+		childCid.String(): child,
+	}}
+
+	r := NewResolver(dag)
+	p := &testPath{root: rootCid, remainder: "child/field"}
+
+	links, remainder, err := r.ResolveLinks(ctx, p)
+	if err != nil {
+		t.Fatalf("ResolveLinks: %s", err)
+	}
+	if len(links) != 1 || !links[0].Cid.Equals(childCid) {
+		t.Fatalf("expected a single link to child, got %+v", links)
+	}
+	if len(remainder) != 1 || remainder[0] != "field" {
+		t.Fatalf("expected remainder [field] naming data inside child, got %v", remainder)
+	}
+
+	nodes, err := r.ResolvePathComponents(ctx, p)
+	if err != nil {
+		t.Fatalf("ResolvePathComponents: %s", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes (root, child), got %d", len(nodes))
+	}
+
+	c, err := r.ResolveToCid(ctx, p)
+	if err != nil {
+		t.Fatalf("ResolveToCid: %s", err)
+	}
+	if !c.Equals(childCid) {
+		t.Fatalf("expected ResolveToCid to stop at child, the last link actually traversed, got %s", c)
+	}
+}
@@ -0,0 +1,155 @@
+package coreapi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	iface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+	"github.com/ipfs/go-ipfs/importer"
+	"github.com/ipfs/go-ipfs/importer/chunk"
+	"github.com/ipfs/go-ipfs/merkledag"
+	uio "github.com/ipfs/go-ipfs/unixfs/io"
+)
+
+// UnixfsAPI implements iface.UnixfsAPI.
+type UnixfsAPI struct {
+	dag merkledag.DAGService
+}
+
+// NewUnixfsAPI builds a UnixfsAPI backed by dag.
+func NewUnixfsAPI(dag merkledag.DAGService) *UnixfsAPI {
+	return &UnixfsAPI{dag: dag}
+}
+
+func (api *UnixfsAPI) Add(ctx context.Context, r io.Reader, opts ...iface.AddOption) (*iface.AddResult, error) {
+	settings := new(iface.AddSettings)
+	for _, opt := range opts {
+		opt(settings)
+	}
+
+	if settings.ShardSize <= 0 {
+		root, err := api.addOne(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		return &iface.AddResult{Root: root}, nil
+	}
+
+	return api.addSharded(ctx, r, settings)
+}
+
+// addOne imports r as a single UnixFS DAG and returns its root path.
+func (api *UnixfsAPI) addOne(ctx context.Context, r io.Reader) (iface.Path, error) {
+	nd, err := importer.BuildDagFromReader(api.dag, chunk.DefaultSplitter(r))
+	if err != nil {
+		return nil, err
+	}
+	return newResolvedPath(nd.Cid()), nil
+}
+
+// addSharded splits r into settings.ShardSize-capped shards, ingesting each
+// as its own pin-root via addOne, then links every shard under a single
+// cluster meta-root so callers can pin shards to different peers for
+// horizontal scaling of very large imports.
+//
+// Boundaries are tracked by shardReader.n, the bytes actually consumed out
+// of r for the current shard, rather than by merkledag.Batch's own size
+// counter: that counter is reset on every internal async commit, so it
+// can't tell a shard boundary from an ordinary batch flush.
+//
+// r is wrapped in a bufio.Reader so each iteration can Peek for remaining
+// data before starting a new shard. Without that, an input whose length is
+// an exact multiple of ShardSize would drive one extra addOne call on an
+// already-exhausted reader, building and persisting a real but orphaned
+// empty UnixFS node every time.
+func (api *UnixfsAPI) addSharded(ctx context.Context, r io.Reader, settings *iface.AddSettings) (*iface.AddResult, error) {
+	var shards []iface.Path
+
+	br := bufio.NewReader(r)
+	for {
+		if _, err := br.Peek(1); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		sr := &shardReader{r: br, max: settings.ShardSize}
+		root, err := api.addOne(ctx, sr)
+		if err != nil {
+			return nil, err
+		}
+
+		shards = append(shards, root)
+		if sr.n < settings.ShardSize {
+			break
+		}
+	}
+
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("unixfs add: empty input")
+	}
+
+	meta, err := api.buildShardManifest(ctx, shards)
+	if err != nil {
+		return nil, err
+	}
+
+	return &iface.AddResult{
+		Root:           meta,
+		Shards:         shards,
+		Meta:           meta,
+		ReplicationMin: settings.ReplicationMin,
+		ReplicationMax: settings.ReplicationMax,
+	}, nil
+}
+
+// buildShardManifest links every shard root under a single UnixFS directory
+// node named by ingestion order, so the meta-root is a real directory: `ipfs
+// ls`/unixfs tooling list shard-0, shard-1, ... like any other directory,
+// rather than a bare protobuf node only this package's own code understands.
+func (api *UnixfsAPI) buildShardManifest(ctx context.Context, shards []iface.Path) (iface.Path, error) {
+	dir := uio.NewDirectory(api.dag)
+	for i, shard := range shards {
+		nd, err := api.dag.Get(ctx, shard.Cid())
+		if err != nil {
+			return nil, err
+		}
+		if err := dir.AddChild(ctx, fmt.Sprintf("shard-%d", i), nd); err != nil {
+			return nil, err
+		}
+	}
+
+	meta, err := dir.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := api.dag.Add(ctx, meta); err != nil {
+		return nil, err
+	}
+
+	return newResolvedPath(meta.Cid()), nil
+}
+
+// shardReader wraps r, reporting via n how many bytes have been read so
+// far so addSharded can detect a shard boundary independent of any
+// batching internal to the DAG builder.
+type shardReader struct {
+	r   io.Reader
+	max int64
+	n   int64
+}
+
+func (s *shardReader) Read(p []byte) (int, error) {
+	if s.n >= s.max {
+		return 0, io.EOF
+	}
+	if remaining := s.max - s.n; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,63 @@
+package coreapi
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	iface "github.com/ipfs/go-ipfs/core/coreapi/interface"
+)
+
+func TestAddWithShardSizeSplitsIntoShards(t *testing.T) {
+	ctx := context.Background()
+	dag := testDagService()
+	api := NewUnixfsAPI(dag)
+
+	const (
+		shardSize = 1 << 10
+		numShards = 3
+	)
+	data := bytes.Repeat([]byte("x"), shardSize*numShards)
+
+	res, err := api.Add(ctx, bytes.NewReader(data), iface.WithShardSize(shardSize), iface.WithShardReplication(1, 3))
+	if err != nil {
+		t.Fatalf("add: %s", err)
+	}
+
+	if len(res.Shards) != numShards {
+		t.Fatalf("expected %d shards, got %d", numShards, len(res.Shards))
+	}
+	if res.Meta == nil || res.Root == nil {
+		t.Fatal("expected both Meta and Root to be populated for a sharded add")
+	}
+	if res.Meta.Cid().String() != res.Root.Cid().String() {
+		t.Fatal("expected Root to equal Meta for a sharded add")
+	}
+	if res.ReplicationMin != 1 || res.ReplicationMax != 3 {
+		t.Fatalf("expected replication bounds 1/3, got %d/%d", res.ReplicationMin, res.ReplicationMax)
+	}
+
+	metaNd, err := dag.Get(ctx, res.Meta.Cid())
+	if err != nil {
+		t.Fatalf("fetching meta-root: %s", err)
+	}
+	if len(metaNd.Links()) != numShards {
+		t.Fatalf("expected meta-root to list %d shard links, got %d", numShards, len(metaNd.Links()))
+	}
+}
+
+func TestAddWithoutShardSizeReturnsSingleRoot(t *testing.T) {
+	ctx := context.Background()
+	api := NewUnixfsAPI(testDagService())
+
+	res, err := api.Add(ctx, bytes.NewReader([]byte("hello world")))
+	if err != nil {
+		t.Fatalf("add: %s", err)
+	}
+	if res.Root == nil {
+		t.Fatal("expected Root to be populated")
+	}
+	if res.Shards != nil || res.Meta != nil {
+		t.Fatal("expected no shard manifest for a non-sharded add")
+	}
+}
@@ -1,21 +1,79 @@
 package merkledag
 
 import (
+	"context"
 	"runtime"
+	"sync"
 
-	node "gx/ipfs/QmNwUEK7QbwSqyKBu3mMtToo8SUc6wQJ7gdZq4gGGJqfnf/go-ipld-format"
+	cid "gx/ipfs/QmNp85zy9RLrQ5oQD4hPyS39ezrrXpcaa7R4Y9kxdWQLLQ/go-cid"
+	node "gx/ipfs/QmPN7cwmpcc4DWXb4KTB9dNAJgjuPY69h3npsMfhRrQL9c/go-ipld-format"
 	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
-	cid "gx/ipfs/QmeSrf6pzut73u6zLQkRFQ3ygt3k6XFT2kjdYP8Tnkwwyg/go-cid"
 )
 
-// ParallelBatchCommits is the number of batch commits that can be in-flight before blocking.
+// ParallelBatchCommits is the default number of batch commits that can be
+// in-flight before blocking. It is used unless a Batch is built with
+// ParallelCommitsBatchOption.
 // TODO(#4299): Experiment with multiple datastores, storage devices, and CPUs to find
 // the right value/formula.
 var ParallelBatchCommits = runtime.NumCPU() * 2
 
+// DAGService is the subset of the DAG-service surface that Batch needs: the
+// generic ipld.DAGService plus the ability to hand out a Batch bound to it.
+// *dagService implements it below; the unexported addBlocks method seals the
+// interface to types in this package.
+type DAGService interface {
+	node.DAGService
+	Batch() *Batch
+	addBlocks(blks []blocks.Block) error
+}
+
+// Batch returns a new Batch for batching adds to this DAGService, bound to
+// context.Background(). Use NewBatchWithContext directly to plumb through a
+// caller's deadline or cancellation.
+func (n *dagService) Batch() *Batch {
+	return NewBatchWithContext(context.Background(), n)
+}
+
+func (n *dagService) addBlocks(blks []blocks.Block) error {
+	_, err := n.Blocks.AddBlocks(blks)
+	return err
+}
+
+const (
+	defaultMaxBatchSize   = 8 << 20 // 8MB
+	defaultMaxBatchBlocks = 128
+)
+
+// BatchOption tunes a single Batch. Options are applied by
+// NewBatchWithContext in the order given, after its own defaults.
+type BatchOption func(*Batch)
+
+// MaxSizeBatchOption overrides the cumulative block size, in bytes, a Batch
+// accumulates before committing.
+func MaxSizeBatchOption(size int) BatchOption {
+	return func(b *Batch) { b.MaxSize = size }
+}
+
+// MaxBlocksBatchOption overrides the number of blocks a Batch accumulates
+// before committing.
+func MaxBlocksBatchOption(num int) BatchOption {
+	return func(b *Batch) { b.MaxBlocks = num }
+}
+
+// ParallelCommitsBatchOption overrides how many commits this Batch may have
+// in flight at once, independent of the package-wide ParallelBatchCommits
+// default.
+func ParallelCommitsBatchOption(max int) BatchOption {
+	return func(b *Batch) { b.parallelCommits = max }
+}
+
 // Batch is a buffer for batching adds to a dag.
 type Batch struct {
-	ds *dagService
+	ds DAGService
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 
 	activeCommits int
 	commitError   error
@@ -24,8 +82,30 @@ type Batch struct {
 	blocks []blocks.Block
 	size   int
 
-	MaxSize   int
-	MaxBlocks int
+	MaxSize         int
+	MaxBlocks       int
+	parallelCommits int
+}
+
+// NewBatchWithContext returns a Batch bound to ds. Outstanding commit
+// goroutines are canceled and drained when ctx is done or Close is called,
+// so long-running ingestion (e.g. CAR import) can tie a Batch's lifetime to
+// a request deadline instead of leaking goroutines if the caller gives up.
+func NewBatchWithContext(ctx context.Context, ds DAGService, opts ...BatchOption) *Batch {
+	ctx, cancel := context.WithCancel(ctx)
+	b := &Batch{
+		ds:              ds,
+		ctx:             ctx,
+		cancel:          cancel,
+		MaxSize:         defaultMaxBatchSize,
+		MaxBlocks:       defaultMaxBatchBlocks,
+		parallelCommits: ParallelBatchCommits,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.commitResults = make(chan error, b.parallelCommits)
+	return b
 }
 
 func (t *Batch) processResults() {
@@ -47,34 +127,50 @@ func (t *Batch) asyncCommit() {
 	if numBlocks == 0 || t.commitError != nil {
 		return
 	}
-	if t.activeCommits >= ParallelBatchCommits {
-		err := <-t.commitResults
-		t.activeCommits--
+	if err := t.ctx.Err(); err != nil {
+		t.commitError = err
+		return
+	}
 
-		if err != nil {
-			t.commitError = err
+	if t.activeCommits >= t.parallelCommits {
+		select {
+		case err := <-t.commitResults:
+			t.activeCommits--
+			if err != nil {
+				t.commitError = err
+				return
+			}
+		case <-t.ctx.Done():
+			t.commitError = t.ctx.Err()
 			return
 		}
 	}
+
+	t.wg.Add(1)
 	go func(b []blocks.Block) {
-		_, err := t.ds.Blocks.AddBlocks(b)
-		t.commitResults <- err
+		defer t.wg.Done()
+		err := t.ds.addBlocks(b)
+		select {
+		case t.commitResults <- err:
+		case <-t.ctx.Done():
+		}
 	}(t.blocks)
 
 	t.activeCommits++
 	t.blocks = make([]blocks.Block, 0, numBlocks)
 	t.size = 0
-
-	return
 }
 
 // Add adds a node to the batch and commits the batch if necessary.
-func (t *Batch) Add(nd node.Node) (*cid.Cid, error) {
+func (t *Batch) Add(ctx context.Context, nd node.Node) (*cid.Cid, error) {
 	// Not strictly necessary but allows us to catch errors early.
 	t.processResults()
 	if t.commitError != nil {
 		return nil, t.commitError
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	t.blocks = append(t.blocks, nd)
 	t.size += len(nd.RawData())
@@ -84,16 +180,33 @@ func (t *Batch) Add(nd node.Node) (*cid.Cid, error) {
 	return nd.Cid(), t.commitError
 }
 
-// Commit commits batched nodes.
-func (t *Batch) Commit() error {
+// Commit commits batched nodes, returning ctx.Err() (or the error of the
+// context the Batch was built with) if either is canceled before every
+// in-flight commit has reported back.
+func (t *Batch) Commit(ctx context.Context) error {
 	t.asyncCommit()
 	for t.activeCommits > 0 && t.commitError == nil {
-		err := <-t.commitResults
-		t.activeCommits--
-		if err != nil {
-			t.commitError = err
+		select {
+		case err := <-t.commitResults:
+			t.activeCommits--
+			if err != nil {
+				t.commitError = err
+			}
+		case <-ctx.Done():
+			t.commitError = ctx.Err()
+		case <-t.ctx.Done():
+			t.commitError = t.ctx.Err()
 		}
 	}
 
 	return t.commitError
 }
+
+// Close cancels any outstanding commit goroutines and waits for them to
+// drain. Callers that abandon a batch after an error, rather than calling
+// Commit to completion, must call Close to avoid leaking goroutines.
+func (t *Batch) Close() error {
+	t.cancel()
+	t.wg.Wait()
+	return t.commitError
+}
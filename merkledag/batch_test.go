@@ -0,0 +1,129 @@
+package merkledag
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cid "gx/ipfs/QmNp85zy9RLrQ5oQD4hPyS39ezrrXpcaa7R4Y9kxdWQLLQ/go-cid"
+	node "gx/ipfs/QmPN7cwmpcc4DWXb4KTB9dNAJgjuPY69h3npsMfhRrQL9c/go-ipld-format"
+	blocks "gx/ipfs/QmYsEQydGrsxNZfAiskvQ76N2xE9hDQtSAkRSynwMiUK3c/go-block-format"
+)
+
+// fakeDAGService is a minimal DAGService stand-in for exercising Batch in
+// isolation, without a real blockstore/exchange. gate, when non-nil, is
+// read from once per addBlocks call before it returns, so a test can hold a
+// commit goroutine open to observe Close draining it.
+type fakeDAGService struct {
+	inFlight int32
+	gate     chan struct{}
+}
+
+func (f *fakeDAGService) addBlocks(blks []blocks.Block) error {
+	atomic.AddInt32(&f.inFlight, 1)
+	defer atomic.AddInt32(&f.inFlight, -1)
+	if f.gate != nil {
+		<-f.gate
+	}
+	return nil
+}
+
+func (f *fakeDAGService) Batch() *Batch {
+	return NewBatchWithContext(context.Background(), f)
+}
+
+func (f *fakeDAGService) Get(ctx context.Context, c *cid.Cid) (node.Node, error) {
+	panic("not implemented: unused by batch tests")
+}
+
+func (f *fakeDAGService) GetMany(ctx context.Context, cs []*cid.Cid) <-chan *node.NodeOption {
+	panic("not implemented: unused by batch tests")
+}
+
+func (f *fakeDAGService) Add(ctx context.Context, nd node.Node) error {
+	panic("not implemented: unused by batch tests")
+}
+
+func (f *fakeDAGService) Remove(ctx context.Context, c *cid.Cid) error {
+	panic("not implemented: unused by batch tests")
+}
+
+func TestBatchCommitHonorsContextCancel(t *testing.T) {
+	ds := &fakeDAGService{gate: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := NewBatchWithContext(ctx, ds, MaxBlocksBatchOption(0))
+	if _, err := b.Add(context.Background(), NodeWithData([]byte("a"))); err != nil {
+		t.Fatalf("add: %s", err)
+	}
+
+	// The commit goroutine spawned by Add is now blocked on ds.gate.
+	cancel()
+
+	commitCtx, commitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer commitCancel()
+	if err := b.Commit(commitCtx); err == nil {
+		t.Fatal("expected Commit to report an error once its context was canceled")
+	}
+
+	close(ds.gate)
+	if err := b.Close(); err == nil {
+		t.Fatal("expected Close to surface the batch's context cancellation")
+	}
+}
+
+func TestBatchCloseDrainsOutstandingCommits(t *testing.T) {
+	ds := &fakeDAGService{gate: make(chan struct{})}
+	b := NewBatchWithContext(context.Background(), ds, MaxBlocksBatchOption(0))
+
+	if _, err := b.Add(context.Background(), NodeWithData([]byte("a"))); err != nil {
+		t.Fatalf("add: %s", err)
+	}
+
+	// Give the async commit goroutine a moment to start and block on the
+	// gate, then let Close race it: Close must not return until the
+	// goroutine has actually finished, not merely been told to stop.
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&ds.inFlight); n != 1 {
+		t.Fatalf("expected 1 in-flight commit before Close, got %d", n)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Close()
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Close returned before the outstanding commit goroutine drained")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(ds.gate)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return after its gated goroutine was released")
+	}
+
+	if n := atomic.LoadInt32(&ds.inFlight); n != 0 {
+		t.Fatalf("expected 0 in-flight commits after Close returned, got %d", n)
+	}
+}
+
+func TestBatchCommitSucceeds(t *testing.T) {
+	ds := &fakeDAGService{}
+	b := NewBatchWithContext(context.Background(), ds)
+
+	for i := 0; i < 10; i++ {
+		if _, err := b.Add(context.Background(), NodeWithData([]byte{byte(i)})); err != nil {
+			t.Fatalf("add %d: %s", i, err)
+		}
+	}
+
+	if err := b.Commit(context.Background()); err != nil {
+		t.Fatalf("commit: %s", err)
+	}
+}